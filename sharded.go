@@ -0,0 +1,151 @@
+package ttlmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"time"
+)
+
+// Hasher assigns a key to a shard by hashing it; ShardedTtlMap reduces
+// the result modulo its shard count to pick the shard.
+type Hasher[K Key] func(key K) uint64
+
+// ShardedTtlMap partitions entries across several independently-locked
+// TtlMap shards, dispatching by a Hasher[K]. A single TtlMap serializes
+// all writes behind one sync.RWMutex, and its full-map prune sweep
+// blocks every reader; sharding is the standard mitigation used by
+// groupcache/leveldb-style caches. Each shard runs its own background
+// pruner, so the shards are otherwise ordinary TtlMap instances.
+type ShardedTtlMap[K Key, V any] struct {
+	shards []*TtlMap[K, V]
+	hash   Hasher[K]
+}
+
+// NewSharded builds a ShardedTtlMap of n shards, each an independent
+// TtlMap constructed with the given per-shard capacity, ttl, and
+// pruneInterval (see New). hasher picks the shard for a key; pass nil
+// to use a default Hasher that hashes strings and the built-in integer
+// types other than uintptr directly and falls back to hashing key's fmt
+// representation for any other comparable type.
+func NewSharded[K Key, V any](n uint, capacity uint, ttl time.Duration, pruneInterval time.Duration, hasher Hasher[K]) *ShardedTtlMap[K, V] {
+	if n == 0 {
+		n = 1
+	}
+	if hasher == nil {
+		hasher = defaultHasher[K]()
+	}
+	shards := make([]*TtlMap[K, V], n)
+	for i := range shards {
+		shards[i] = New[K, V](capacity, ttl, pruneInterval)
+	}
+	return &ShardedTtlMap[K, V]{shards: shards, hash: hasher}
+}
+
+// shardFor returns the shard key is assigned to.
+func (m *ShardedTtlMap[K, V]) shardFor(key K) *TtlMap[K, V] {
+	return m.shards[m.hash(key)%uint64(len(m.shards))]
+}
+
+// Shards returns the number of shards in m.
+func (m *ShardedTtlMap[K, V]) Shards() uint {
+	return uint(len(m.shards))
+}
+
+func (m *ShardedTtlMap[K, V]) Put(key K, value V) {
+	m.shardFor(key).Put(key, value)
+}
+
+// PutWithTTL is the sharded equivalent of TtlMap.PutWithTTL.
+func (m *ShardedTtlMap[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	m.shardFor(key).PutWithTTL(key, value, ttl)
+}
+
+// SetWithExpiration is the sharded equivalent of TtlMap.SetWithExpiration.
+func (m *ShardedTtlMap[K, V]) SetWithExpiration(key K, value V, expiration time.Time) {
+	m.shardFor(key).SetWithExpiration(key, value, expiration)
+}
+
+func (m *ShardedTtlMap[K, V]) Get(key K) (value V, ok bool) {
+	return m.shardFor(key).Get(key)
+}
+
+func (m *ShardedTtlMap[K, V]) GetOrZero(key K) (value V) {
+	return m.shardFor(key).GetOrZero(key)
+}
+
+func (m *ShardedTtlMap[K, V]) Delete(key K) bool {
+	return m.shardFor(key).Delete(key)
+}
+
+// Len returns the total number of live entries across all shards.
+func (m *ShardedTtlMap[K, V]) Len() (size uint) {
+	for _, shard := range m.shards {
+		size += shard.Len()
+	}
+	return
+}
+
+// Clear empties every shard.
+func (m *ShardedTtlMap[K, V]) Clear() {
+	for _, shard := range m.shards {
+		shard.Clear()
+	}
+}
+
+// OnEvict registers f on every shard; see TtlMap.OnEvict.
+func (m *ShardedTtlMap[K, V]) OnEvict(f func(K, V, EvictReason)) {
+	for _, shard := range m.shards {
+		shard.OnEvict(f)
+	}
+}
+
+// Close stops every shard's pruner goroutine and clears it.
+func (m *ShardedTtlMap[K, V]) Close() {
+	for _, shard := range m.shards {
+		shard.Close()
+	}
+}
+
+// defaultHasher returns a Hasher seeded once at creation that hashes
+// strings and the built-in integer types other than uintptr directly via
+// hash/maphash, falling back to hashing key's fmt.Sprintf representation
+// for any other comparable type, including named integer types.
+func defaultHasher[K Key]() Hasher[K] {
+	seed := maphash.MakeSeed()
+	return func(key K) uint64 {
+		switch k := any(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			return hashUint64(seed, uint64(k))
+		case int8:
+			return hashUint64(seed, uint64(k))
+		case int16:
+			return hashUint64(seed, uint64(k))
+		case int32:
+			return hashUint64(seed, uint64(k))
+		case int64:
+			return hashUint64(seed, uint64(k))
+		case uint:
+			return hashUint64(seed, uint64(k))
+		case uint8:
+			return hashUint64(seed, uint64(k))
+		case uint16:
+			return hashUint64(seed, uint64(k))
+		case uint32:
+			return hashUint64(seed, uint64(k))
+		case uint64:
+			return hashUint64(seed, k)
+		default:
+			return maphash.String(seed, fmt.Sprintf("%v", k))
+		}
+	}
+}
+
+// hashUint64 hashes v's little-endian byte representation with seed.
+func hashUint64(seed maphash.Seed, v uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return maphash.Bytes(seed, buf[:])
+}
@@ -0,0 +1,155 @@
+package ttlmap
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedPutGet(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(0)
+	pruneInterval := time.Second * 10
+	sm := NewSharded[string, int](16, capacity, ttl, pruneInterval, nil)
+	defer sm.Close()
+
+	for i := 0; i < 100; i++ {
+		sm.Put(fmt.Sprintf("key-%d", i), i)
+	}
+	if sm.Len() != 100 {
+		t.Fatalf("sm.Len should equal 100, but actually equals %v\n", sm.Len())
+	}
+	for i := 0; i < 100; i++ {
+		value, ok := sm.Get(fmt.Sprintf("key-%d", i))
+		if !ok || value != i {
+			t.Fatalf("key-%d should equal %v, got value=%v ok=%v", i, i, value, ok)
+		}
+	}
+}
+
+func TestShardedDistributesAcrossShards(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(0)
+	pruneInterval := time.Second * 10
+	sm := NewSharded[int, int](8, capacity, ttl, pruneInterval, nil)
+	defer sm.Close()
+
+	seenShards := map[*TtlMap[int, int]]bool{}
+	for i := 0; i < 200; i++ {
+		seenShards[sm.shardFor(i)] = true
+	}
+	if len(seenShards) < 2 {
+		t.Fatalf("expected keys to spread across more than 1 shard, got %v", len(seenShards))
+	}
+}
+
+func TestShardedDelete(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(0)
+	pruneInterval := time.Second * 10
+	sm := NewSharded[string, string](4, capacity, ttl, pruneInterval, nil)
+	defer sm.Close()
+
+	sm.Put("a", "1")
+	sm.Put("b", "2")
+
+	if !sm.Delete("a") {
+		t.Fatalf("Delete should return true for a present key")
+	}
+	if sm.Delete("a") {
+		t.Fatalf("Delete should return false the second time")
+	}
+	if sm.Len() != 1 {
+		t.Fatalf("sm.Len should equal 1, but actually equals %v\n", sm.Len())
+	}
+}
+
+func TestShardedConcurrentAccess(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(0)
+	pruneInterval := time.Second * 10
+	sm := NewSharded[int, int](16, capacity, ttl, pruneInterval, nil)
+	defer sm.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 32; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := worker*200 + i
+				sm.Put(key, key)
+				sm.Get(key)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if sm.Len() != 32*200 {
+		t.Fatalf("sm.Len should equal %v, but actually equals %v\n", 32*200, sm.Len())
+	}
+}
+
+// benchmarkShardedMixed drives a mixed Get/Put workload against a
+// ShardedTtlMap with the given shard count, for comparison against the
+// single-shard (unsharded) baseline below.
+func benchmarkShardedMixed(b *testing.B, shards uint) {
+	ttl := time.Minute
+	capacity := uint(0)
+	pruneInterval := time.Minute
+	sm := NewSharded[int, int](shards, capacity, ttl, pruneInterval, nil)
+	defer sm.Close()
+
+	const keySpace = 10000
+	for i := 0; i < keySpace; i++ {
+		sm.Put(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(1))
+		for pb.Next() {
+			key := r.Intn(keySpace)
+			if r.Intn(10) == 0 {
+				sm.Put(key, key)
+			} else {
+				sm.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkShardedMixed_1Shard(b *testing.B)    { benchmarkShardedMixed(b, 1) }
+func BenchmarkShardedMixed_16Shards(b *testing.B)  { benchmarkShardedMixed(b, 16) }
+func BenchmarkShardedMixed_64Shards(b *testing.B)  { benchmarkShardedMixed(b, 64) }
+func BenchmarkShardedMixed_256Shards(b *testing.B) { benchmarkShardedMixed(b, 256) }
+
+// BenchmarkUnshardedMixed is the plain TtlMap baseline that the
+// BenchmarkShardedMixed_* variants above are compared against.
+func BenchmarkUnshardedMixed(b *testing.B) {
+	ttl := time.Minute
+	capacity := uint(0)
+	pruneInterval := time.Minute
+	tm := New[int, int](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	const keySpace = 10000
+	for i := 0; i < keySpace; i++ {
+		tm.Put(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(1))
+		for pb.Next() {
+			key := r.Intn(keySpace)
+			if r.Intn(10) == 0 {
+				tm.Put(key, key)
+			} else {
+				tm.Get(key)
+			}
+		}
+	})
+}
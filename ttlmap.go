@@ -16,50 +16,123 @@ Adopted from: https://stackoverflow.com/a/25487392/452281
 
 package ttlmap
 
-// TODO make it so that multiple operations are possible without having to unlock and lock again
-// TODO refresh TTLs of items: e.g. Touch() method, PutIfNew() method
-// TODO entry API
-// TODO TTLs per entry (second map type)
 // TODO switch from UNIX timestamp to some base time created in New()
 
 import (
-	"maps"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"iter"
+	"os"
 	"sync"
 	"time"
 )
 
 const version string = "0.1.1"
 
+// NoExpiration is a sentinel TTL that marks an entry as never expiring.
+// Pass it to PutWithTTL to opt an entry out of both the map-wide ttl and
+// the prune loop.
+const NoExpiration time.Duration = -1
+
 type Key interface {
 	comparable
 }
 
-// item is an entry in a TtlMap.
-type item[V any] struct {
+// EvictReason describes why an entry left a TtlMap, passed to the
+// callback registered via OnEvict.
+type EvictReason int
+
+const (
+	// Expired means the entry's TTL ran out and it was removed, either by
+	// the pruner or lazily by an access such as Get, Len, Update or Touch.
+	Expired EvictReason = iota
+	// Capacity means the entry was the least recently used one and was
+	// evicted to keep the map at or under its capacity.
+	Capacity
+	// Manual means the entry was removed by an explicit Delete or
+	// DeleteLRU call, an Update returning keep=false, or Entry.Remove.
+	Manual
+)
+
+// item is an entry in a TtlMap. Besides its value and expiration, it is
+// also a node in the map's intrusive LRU list threaded through prev/next.
+type item[K Key, V any] struct {
+	// key is the entry's key, kept here so the LRU list can delete the
+	// entry from entries without a reverse lookup.
+	key K
 	// value is the value of the item.
 	value V
-	// expires is the nanos UNIX timestamp when the item expires.
+	// expires is the nanos UNIX timestamp when the item expires. A value
+	// of 0 means the item never expires and is skipped by the pruner.
 	expires int64
+	// prev and next link this item into the LRU list, from most to
+	// least recently used. Both are nil when the item is unlinked.
+	prev, next *item[K, V]
+}
+
+// PruneStrategy selects how the background pruner goroutine scans a
+// TtlMap for expired entries. Use FullScan or Sampled to build one.
+type PruneStrategy struct {
+	sampled      bool
+	sampleSize   uint
+	sampleBudget time.Duration
 }
 
+// FullScan walks every entry under the write lock on each tick, as
+// TtlMap has always done. Simple and fine for small-to-medium maps, but
+// it can hold the write lock for the whole map size on each tick.
+var FullScan = PruneStrategy{}
+
+// Sampled checks up to n entries per round, relying on Go's randomized
+// map iteration order to approximate a random sample, and evicts any
+// that have expired. If more than 25% of a round's sample was expired,
+// it immediately samples another round, repeating until a round is
+// mostly live or budget has elapsed since the tick started. This bounds
+// worst-case pause time on large maps compared to FullScan, at the cost
+// of expired entries sometimes lingering a little past their TTL.
+func Sampled(n uint, budget time.Duration) PruneStrategy {
+	return PruneStrategy{sampled: true, sampleSize: n, sampleBudget: budget}
+}
+
+// sampledExpiredThreshold is the fraction of a sampled round that must
+// be expired to trigger another round immediately.
+const sampledExpiredThreshold = 0.25
+
 type TtlMap[K Key, V any] struct {
 	// entries are the elements in this TtlMap.
-	entries map[K]*item[V]
+	entries map[K]*item[K, V]
+	// capacity is the maximum number of entries this TtlMap holds. Once
+	// exceeded on Put, the least recently used entries are evicted until
+	// back under capacity. A capacity of 0 means unbounded.
+	capacity uint
 	// ttl is the time-to-live of each element. Saved as number of nanoseconds.
 	ttl time.Duration
 	// lock is the lock for synchronizing access to entries.
 	lock sync.RWMutex
 	// stop is the channel for stopping the prune goroutine.
 	stop chan<- struct{}
+	// head and tail are the most and least recently used items in the
+	// LRU list, respectively.
+	head, tail *item[K, V]
+	// onEvict, if set, is called whenever an entry leaves the map.
+	onEvict func(K, V, EvictReason)
 }
 
 func New[K Key, V any](capacity uint, ttl time.Duration, pruneInterval time.Duration) (m *TtlMap[K, V]) {
+	return NewWithOptions[K, V](capacity, ttl, pruneInterval, FullScan)
+}
+
+// NewWithOptions is like New, but lets the caller pick the background
+// pruner's PruneStrategy instead of defaulting to FullScan.
+func NewWithOptions[K Key, V any](capacity uint, ttl time.Duration, pruneInterval time.Duration, strategy PruneStrategy) (m *TtlMap[K, V]) {
 
 	stop := make(chan struct{})
 	m = &TtlMap[K, V]{
-		entries: make(map[K]*item[V], capacity),
-		ttl:     ttl,
-		stop:    stop,
+		entries:  make(map[K]*item[K, V], capacity),
+		capacity: capacity,
+		ttl:      ttl,
+		stop:     stop,
 	}
 
 	go func() {
@@ -70,84 +143,583 @@ func New[K Key, V any](capacity uint, ttl time.Duration, pruneInterval time.Dura
 			case <-stop:
 				return
 			case now := <-ticker.C:
-				currentTime := now.UnixNano()
-				m.lock.Lock()
-				for k, v := range m.entries {
-					// print("TICK:", currentTime, "  ", v.lastAccess, "  ", currentTime-v.lastAccess, "  ", ttl, "  ", k, "\n")
-					if currentTime >= v.expires {
-						delete(m.entries, k)
-						// print("deleting: ", k, "\n")
-					}
+				if strategy.sampled {
+					m.pruneSampled(now.UnixNano(), strategy)
+				} else {
+					m.pruneFullScan(now.UnixNano())
 				}
-				// print("----\n")
-				m.lock.Unlock()
 			}
 		}
 	}()
 	return
 }
 
+// pruneFullScan walks every entry and evicts the expired ones.
+func (m *TtlMap[K, V]) pruneFullScan(now int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, it := range m.entries {
+		if m.expired(it, now) {
+			m.removeExpired(it)
+		}
+	}
+}
+
+// pruneSampled repeatedly samples up to strategy.sampleSize entries,
+// evicting expired ones, until a round is mostly live or the strategy's
+// time budget runs out.
+func (m *TtlMap[K, V]) pruneSampled(now int64, strategy PruneStrategy) {
+	deadline := time.Now().Add(strategy.sampleBudget)
+	for {
+		expired, sampled := m.sampleAndEvict(now, strategy.sampleSize)
+		if sampled == 0 || float64(expired)/float64(sampled) <= sampledExpiredThreshold {
+			return
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+// sampleAndEvict evicts expired entries among up to n entries visited in
+// Go's (randomized) map iteration order, approximating a random sample.
+func (m *TtlMap[K, V]) sampleAndEvict(now int64, n uint) (expired, sampled uint) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, it := range m.entries {
+		if sampled >= n {
+			break
+		}
+		sampled++
+		if m.expired(it, now) {
+			expired++
+			m.removeExpired(it)
+		}
+	}
+	return
+}
+
+// NewSerializable is like New, but documents that the returned TtlMap's
+// V is expected to be gob-encodable so that SaveTo/SaveFile and
+// LoadFrom/LoadFile can be used on it. If V is, or contains, an
+// interface type, register its concrete types with gob.Register before
+// calling SaveTo or LoadFrom.
+func NewSerializable[K Key, V any](capacity uint, ttl time.Duration, pruneInterval time.Duration) (m *TtlMap[K, V]) {
+	return New[K, V](capacity, ttl, pruneInterval)
+}
+
+// OnEvict registers a callback invoked whenever an entry leaves the map,
+// whether through TTL expiration, LRU eviction under capacity pressure,
+// or an explicit removal (Delete, DeleteLRU, Update with keep=false,
+// Entry.Remove). Clear and Close drop entries without calling it. It
+// replaces any previously registered callback. The callback runs while
+// the write lock is held,
+// so it must not call back into this TtlMap.
+func (m *TtlMap[K, V]) OnEvict(f func(K, V, EvictReason)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.onEvict = f
+}
+
+// Len reports the number of live, unexpired entries. Entries whose TTL
+// has run out but which the background pruner hasn't reached yet are
+// not counted, and are swept up as a side effect of this call.
 func (m *TtlMap[K, V]) Len() (size uint) {
+	now := time.Now().UnixNano()
 	m.lock.RLock()
-	size = uint(len(m.entries))
-	defer m.lock.RUnlock()
+	var staleKeys []K
+	for k, it := range m.entries {
+		if m.expired(it, now) {
+			staleKeys = append(staleKeys, k)
+		}
+	}
+	size = uint(len(m.entries) - len(staleKeys))
+	m.lock.RUnlock()
+
+	if len(staleKeys) == 0 {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, k := range staleKeys {
+		if it, ok := m.entries[k]; ok && m.expired(it, now) {
+			m.removeExpired(it)
+		}
+	}
 	return
 }
 
 func (m *TtlMap[K, V]) Put(key K, value V) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	m.entries[key] = &item[V]{
-		value:   value,
-		expires: time.Now().Add(m.ttl).UnixNano(),
+	m.insert(key, value, time.Now().Add(m.ttl).UnixNano())
+}
+
+// PutWithTTL stores value under key like Put, but overrides the map-wide
+// ttl for this entry only. Pass NoExpiration for an entry that should
+// never be pruned.
+func (m *TtlMap[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.insert(key, value, expiresAt(ttl))
+}
+
+// SetWithExpiration stores value under key, expiring it at the given
+// absolute time instead of a duration from now.
+func (m *TtlMap[K, V]) SetWithExpiration(key K, value V, expiration time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.insert(key, value, expiration.UnixNano())
+}
+
+// insert stores value under key with the given absolute expiration,
+// threading the new item onto the front of the LRU list and evicting
+// from the tail if this pushes the map over capacity. Callers must hold
+// the write lock.
+func (m *TtlMap[K, V]) insert(key K, value V, expires int64) {
+	if old, ok := m.entries[key]; ok {
+		m.listRemove(old)
+	}
+	it := &item[K, V]{key: key, value: value, expires: expires}
+	m.entries[key] = it
+	m.listPushFront(it)
+	if m.capacity == 0 {
+		return
+	}
+	for uint(len(m.entries)) > m.capacity {
+		m.evictTail(Capacity)
 	}
 }
 
+// expiresAt converts a TTL duration into an absolute nanos UNIX
+// expiration timestamp, mapping NoExpiration to the pruner's sentinel.
+func expiresAt(ttl time.Duration) int64 {
+	if ttl == NoExpiration {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// Get retrieves the value stored under key. A successful lookup also
+// counts as a use for LRU purposes, which moves the entry to the front
+// of the eviction list; since that bookkeeping mutates the list, Get
+// takes the full write lock rather than RLock. The alternative -
+// guarding the list with its own mutex so Get could keep RLock - was
+// passed over because it would let a read race a concurrent prune or
+// capacity eviction on the very entry being touched.
 func (m *TtlMap[K, V]) Get(key K) (value V, ok bool) {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
-	var item *item[V]
-	if item, ok = m.entries[key]; ok {
-		value = item.value
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	it, found := m.entries[key]
+	if !found {
+		return
+	}
+	if m.expired(it, time.Now().UnixNano()) {
+		m.removeExpired(it)
+		return
 	}
+	value, ok = it.value, true
+	m.touch(it)
 	return
 }
 
 func (m *TtlMap[K, V]) GetOrZero(key K) (value V) {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
-	if it, ok := m.entries[key]; ok {
-		value = it.value
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	it, found := m.entries[key]
+	if !found {
+		return
+	}
+	if m.expired(it, time.Now().UnixNano()) {
+		m.removeExpired(it)
+		return
 	}
+	value = it.value
+	m.touch(it)
 	return
 }
 
 func (m *TtlMap[K, V]) Delete(key K) bool {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	_, ok := m.entries[key]
+	it, ok := m.entries[key]
 	if !ok {
 		return false
 	}
 	delete(m.entries, key)
+	m.listRemove(it)
+	if m.onEvict != nil {
+		m.onEvict(it.key, it.value, Manual)
+	}
 	return true
 }
 
-func (m *TtlMap[K, V]) Clear() {
+// DeleteLRU evicts up to n of the least recently used entries, for
+// manually relieving memory pressure. It returns the number of entries
+// actually removed, which is less than n once the map is empty.
+func (m *TtlMap[K, V]) DeleteLRU(n uint) (removed uint) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	clear(m.entries)
+	for removed < n && m.evictTail(Manual) {
+		removed++
+	}
+	return
+}
+
+// Update atomically reads, computes, and writes back the value under
+// key while holding a single write lock, closing the race where a
+// caller's Get followed by a separate Put could interleave with the
+// pruner, an LRU eviction, or another goroutine's Put. f is called with
+// the current value (the zero value if key is absent or has already
+// expired) and whether it exists; it returns the value to store and
+// whether to keep the entry. Returning keep=false removes the entry
+// (a no-op if it wasn't present). A kept entry that already existed
+// keeps its current expiration - including NoExpiration set via
+// PutWithTTL/SetWithExpiration - so Update never silently shortens or
+// lengthens an entry's TTL; only a brand-new entry gets the map's
+// default ttl, same as Put. Use Touch to explicitly refresh an existing
+// entry's TTL.
+func (m *TtlMap[K, V]) Update(key K, f func(old V, exists bool) (new V, keep bool)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	it, found := m.entries[key]
+	if found && m.expired(it, time.Now().UnixNano()) {
+		m.removeExpired(it)
+		it, found = nil, false
+	}
+
+	var old V
+	if found {
+		old = it.value
+	}
+	newValue, keep := f(old, found)
+	if !keep {
+		if found {
+			delete(m.entries, key)
+			m.listRemove(it)
+			if m.onEvict != nil {
+				m.onEvict(key, it.value, Manual)
+			}
+		}
+		return
+	}
+	expires := expiresAt(m.ttl)
+	if found {
+		expires = it.expires
+	}
+	m.insert(key, newValue, expires)
+}
+
+// Touch refreshes key's expiration to the map's default ttl without
+// changing its value, reporting whether key was present.
+func (m *TtlMap[K, V]) Touch(key K) (found bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	it, ok := m.entries[key]
+	if !ok || m.expired(it, time.Now().UnixNano()) {
+		if ok {
+			m.removeExpired(it)
+		}
+		return false
+	}
+	m.insert(key, it.value, expiresAt(m.ttl))
+	return true
+}
+
+// PutIfAbsent stores value under key only if key is not already present,
+// returning the value now stored under key and whether it was just
+// inserted. It is a thin wrapper around Update.
+func (m *TtlMap[K, V]) PutIfAbsent(key K, value V) (actual V, inserted bool) {
+	m.Update(key, func(old V, exists bool) (V, bool) {
+		if exists {
+			actual = old
+			return old, true
+		}
+		actual, inserted = value, true
+		return value, true
+	})
+	return
+}
+
+// Entry returns a handle to key's slot in m, for inserting, mutating, or
+// removing a value without a separate Get-then-Put round trip.
+func (m *TtlMap[K, V]) Entry(key K) Entry[K, V] {
+	return Entry[K, V]{m: m, key: key}
+}
+
+// Entry is a handle to a single key's slot in a TtlMap, returned by
+// TtlMap.Entry. Each method performs its operation through one Update
+// call, so each single call is atomic; a chain such as
+// AndModify(f).OrInsert(v) takes the lock once per call, and other
+// goroutines can act on the key between the steps.
+type Entry[K Key, V any] struct {
+	m   *TtlMap[K, V]
+	key K
+}
+
+// OrInsert returns the value already stored for the entry's key, or
+// stores and returns value if the key is absent.
+func (e Entry[K, V]) OrInsert(value V) (result V) {
+	e.m.Update(e.key, func(old V, exists bool) (V, bool) {
+		if exists {
+			result = old
+			return old, true
+		}
+		result = value
+		return value, true
+	})
+	return
+}
+
+// OrInsertWith is like OrInsert, but only calls f to produce the value
+// when the key is absent, for when constructing value is expensive.
+func (e Entry[K, V]) OrInsertWith(f func() V) (result V) {
+	e.m.Update(e.key, func(old V, exists bool) (V, bool) {
+		if exists {
+			result = old
+			return old, true
+		}
+		result = f()
+		return result, true
+	})
+	return
+}
+
+// AndModify calls f with a pointer to the entry's value if it exists,
+// letting the caller mutate it in place, and is a no-op if the key is
+// absent. It returns e so calls can be chained, e.g.
+// m.Entry(k).AndModify(f).OrInsert(v).
+func (e Entry[K, V]) AndModify(f func(*V)) Entry[K, V] {
+	e.m.Update(e.key, func(old V, exists bool) (V, bool) {
+		if !exists {
+			return old, false
+		}
+		f(&old)
+		return old, true
+	})
+	return e
+}
+
+// Remove deletes the entry's key if present, returning the removed
+// value and whether it was present.
+func (e Entry[K, V]) Remove() (value V, removed bool) {
+	e.m.Update(e.key, func(old V, exists bool) (V, bool) {
+		if exists {
+			value, removed = old, true
+		}
+		return old, false
+	})
+	return
 }
 
-func (m *TtlMap[K, V]) Copy() map[K]*item[V] {
+func (m *TtlMap[K, V]) Clear() {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	dst := make(map[K]*item[V], len(m.entries))
-	maps.Copy(dst, m.entries)
-	return dst
+	clear(m.entries)
+	m.head, m.tail = nil, nil
+}
+
+// All returns an iterator over all of m's live key/value pairs, skipping
+// any entry whose expiration has passed. The iterator holds RLock for
+// the duration of the loop, so the consuming loop must not call back
+// into m (e.g. Put or Delete) or it will deadlock.
+func (m *TtlMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+		now := time.Now().UnixNano()
+		for k, it := range m.entries {
+			if m.expired(it, now) {
+				continue
+			}
+			if !yield(k, it.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over all of m's live keys. See All for
+// locking and expiration semantics.
+func (m *TtlMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over all of m's live values. See All for
+// locking and expiration semantics.
+func (m *TtlMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range m.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ForEach calls f for each of m's live key/value pairs, stopping early
+// if f returns false. It is equivalent to ranging over All, in callback
+// form. Like the rest of the package, it needs Go 1.23 or later to build.
+func (m *TtlMap[K, V]) ForEach(f func(K, V) bool) {
+	for k, v := range m.All() {
+		if !f(k, v) {
+			return
+		}
+	}
 }
 
 func (m *TtlMap[K, V]) Close() {
 	m.stop <- struct{}{}
 	m.Clear()
 }
+
+// snapshotEntry is the on-disk/on-wire representation of one TtlMap
+// entry used by SaveTo/LoadFrom. Fields are exported so encoding/gob can
+// see them.
+type snapshotEntry[K Key, V any] struct {
+	Key     K
+	Value   V
+	Expires int64
+}
+
+// SaveTo serializes every entry - key, value, and absolute expiration -
+// to w using encoding/gob, so a process can persist a TtlMap on shutdown
+// and later restore it with LoadFrom, preserving remaining TTLs. V must
+// be gob-encodable; see NewSerializable.
+func (m *TtlMap[K, V]) SaveTo(w io.Writer) error {
+	m.lock.RLock()
+	entries := make([]snapshotEntry[K, V], 0, len(m.entries))
+	for k, it := range m.entries {
+		entries = append(entries, snapshotEntry[K, V]{Key: k, Value: it.value, Expires: it.expires})
+	}
+	m.lock.RUnlock()
+
+	if err := gob.NewEncoder(w).Encode(entries); err != nil {
+		return fmt.Errorf("ttlmap: encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// SaveFile is a convenience wrapper around SaveTo that writes the
+// snapshot to the file at path, creating or truncating it.
+func (m *TtlMap[K, V]) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ttlmap: save file: %w", err)
+	}
+	defer f.Close()
+	return m.SaveTo(f)
+}
+
+// LoadFrom restores entries previously written by SaveTo, merging them
+// into the existing entries via the normal Put path (so LRU and
+// capacity eviction still apply). Entries whose expiration has already
+// passed are dropped rather than loaded.
+func (m *TtlMap[K, V]) LoadFrom(r io.Reader) error {
+	var entries []snapshotEntry[K, V]
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("ttlmap: decode snapshot: %w", err)
+	}
+
+	now := time.Now().UnixNano()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, e := range entries {
+		if e.Expires != 0 && now >= e.Expires {
+			continue
+		}
+		m.insert(e.Key, e.Value, e.Expires)
+	}
+	return nil
+}
+
+// LoadFile is a convenience wrapper around LoadFrom that reads the
+// snapshot from the file at path.
+func (m *TtlMap[K, V]) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ttlmap: load file: %w", err)
+	}
+	defer f.Close()
+	return m.LoadFrom(f)
+}
+
+// listPushFront inserts it at the head of the LRU list, marking it as
+// the most recently used entry. Callers must hold the write lock.
+func (m *TtlMap[K, V]) listPushFront(it *item[K, V]) {
+	it.prev = nil
+	it.next = m.head
+	if m.head != nil {
+		m.head.prev = it
+	}
+	m.head = it
+	if m.tail == nil {
+		m.tail = it
+	}
+}
+
+// listRemove unlinks it from the LRU list. Callers must hold the write
+// lock.
+func (m *TtlMap[K, V]) listRemove(it *item[K, V]) {
+	if it.prev != nil {
+		it.prev.next = it.next
+	} else {
+		m.head = it.next
+	}
+	if it.next != nil {
+		it.next.prev = it.prev
+	} else {
+		m.tail = it.prev
+	}
+	it.prev, it.next = nil, nil
+}
+
+// touch moves it to the front of the LRU list, marking it as the most
+// recently used entry. Callers must hold the write lock.
+func (m *TtlMap[K, V]) touch(it *item[K, V]) {
+	if m.head == it {
+		return
+	}
+	m.listRemove(it)
+	m.listPushFront(it)
+}
+
+// expired reports whether it had already expired as of now. An item
+// whose expires is 0 (see NoExpiration) never expires.
+func (m *TtlMap[K, V]) expired(it *item[K, V], now int64) bool {
+	return it.expires != 0 && now >= it.expires
+}
+
+// removeExpired deletes it from entries and the LRU list and reports it
+// to onEvict with reason Expired. Callers must hold the write lock.
+func (m *TtlMap[K, V]) removeExpired(it *item[K, V]) {
+	delete(m.entries, it.key)
+	m.listRemove(it)
+	if m.onEvict != nil {
+		m.onEvict(it.key, it.value, Expired)
+	}
+}
+
+// evictTail removes the least recently used entry, if any, and reports
+// its removal to onEvict with the given reason. It returns false if the
+// map was empty. Callers must hold the write lock.
+func (m *TtlMap[K, V]) evictTail(reason EvictReason) bool {
+	it := m.tail
+	if it == nil {
+		return false
+	}
+	delete(m.entries, it.key)
+	m.listRemove(it)
+	if m.onEvict != nil {
+		m.onEvict(it.key, it.value, reason)
+	}
+	return true
+}
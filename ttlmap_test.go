@@ -1,6 +1,8 @@
 package ttlmap
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -34,7 +36,10 @@ func TestNoItemsExpired(t *testing.T) {
 	tm.Put("myString", "a b c")
 	tm.Put("int_array", []int{1, 2, 3})
 
-	time.Sleep(ttl)
+	// Sleep for less than ttl: Len() now lazily checks expiration, so
+	// sleeping for the full ttl (or longer) would race the entries
+	// actually expiring.
+	time.Sleep(ttl / 2)
 	t.Logf("tm.len: %v\n", tm.Len())
 	if tm.Len() != 2 {
 		t.Fatalf("t.Len should equal 2, but actually equals %v\n", tm.Len())
@@ -250,6 +255,459 @@ func TestClear(t *testing.T) {
 //	}
 //
 
+func TestPutWithTTL(t *testing.T) {
+	ttl := time.Second * 4
+	capacity := uint(3)
+	pruneInterval := time.Second * 1
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	// populate the TtlMap
+	tm.Put("default", "expires with map ttl")
+	tm.PutWithTTL("short", "expires quickly", time.Second*1)
+	tm.PutWithTTL("forever", "never expires", NoExpiration)
+
+	time.Sleep(time.Second * 2)
+	if _, ok := tm.Get("short"); ok {
+		t.Fatalf("\"short\" should have expired by now")
+	}
+	if value, ok := tm.Get("default"); !ok || value != "expires with map ttl" {
+		t.Fatalf("\"default\" should still be present, got value=%v ok=%v", value, ok)
+	}
+
+	time.Sleep(ttl + pruneInterval)
+	if value, ok := tm.Get("forever"); !ok || value != "never expires" {
+		t.Fatalf("\"forever\" should never expire, got value=%v ok=%v", value, ok)
+	}
+	if tm.Len() != 1 {
+		t.Fatalf("tm.Len should equal 1, but actually equals %v\n", tm.Len())
+	}
+}
+
+func TestUpdatePreservesExistingExpiration(t *testing.T) {
+	ttl := time.Second * 1
+	capacity := uint(3)
+	pruneInterval := time.Hour
+	tm := New[string, int](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.PutWithTTL("forever", 1, NoExpiration)
+
+	// None of these touch the map's default ttl, so "forever" should
+	// still never expire afterwards.
+	tm.Entry("forever").AndModify(func(v *int) { *v += 1 })
+	tm.Entry("forever").OrInsert(100)
+	tm.PutIfAbsent("forever", 100)
+
+	time.Sleep(ttl + time.Millisecond*100)
+	if value, ok := tm.Get("forever"); !ok || value != 2 {
+		t.Fatalf("\"forever\" should still equal 2 and never expire, got value=%v ok=%v", value, ok)
+	}
+}
+
+func TestSetWithExpiration(t *testing.T) {
+	ttl := time.Second * 4
+	capacity := uint(3)
+	pruneInterval := time.Second * 1
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.SetWithExpiration("soon", "a b c", time.Now().Add(time.Second*1))
+
+	time.Sleep(time.Second * 2)
+	if _, ok := tm.Get("soon"); ok {
+		t.Fatalf("\"soon\" should have expired by now")
+	}
+}
+
+func TestLRUCapacityEviction(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(2)
+	pruneInterval := time.Second * 10
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	var evicted []string
+	tm.OnEvict(func(key string, value string, reason EvictReason) {
+		if reason != Capacity {
+			t.Errorf("expected eviction reason Capacity, got %v", reason)
+		}
+		evicted = append(evicted, key)
+	})
+
+	tm.Put("a", "1")
+	tm.Put("b", "2")
+	tm.Get("a") // "a" is now most recently used, "b" is least recently used
+	tm.Put("c", "3")
+
+	if tm.Len() != 2 {
+		t.Fatalf("tm.Len should equal 2, but actually equals %v\n", tm.Len())
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatalf("expected \"b\" to be evicted, got %v", evicted)
+	}
+	if _, ok := tm.Get("a"); !ok {
+		t.Fatalf("\"a\" should still be present")
+	}
+	if _, ok := tm.Get("c"); !ok {
+		t.Fatalf("\"c\" should still be present")
+	}
+}
+
+func TestDeleteLRU(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(3)
+	pruneInterval := time.Second * 10
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("a", "1")
+	tm.Put("b", "2")
+	tm.Put("c", "3")
+
+	removed := tm.DeleteLRU(2)
+	if removed != 2 {
+		t.Fatalf("DeleteLRU should have removed 2 entries, but removed %v\n", removed)
+	}
+	if tm.Len() != 1 {
+		t.Fatalf("tm.Len should equal 1, but actually equals %v\n", tm.Len())
+	}
+	if _, ok := tm.Get("c"); !ok {
+		t.Fatalf("\"c\" should still be present as the most recently used entry")
+	}
+}
+
+func TestLazyExpirationOnGet(t *testing.T) {
+	ttl := time.Second * 1
+	capacity := uint(3)
+	pruneInterval := time.Hour // long enough that the ticker never fires during the test
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("myString", "a b c")
+	time.Sleep(ttl + time.Millisecond*100)
+
+	if _, ok := tm.Get("myString"); ok {
+		t.Fatalf("Get should treat the expired entry as absent")
+	}
+	if tm.Len() != 0 {
+		t.Fatalf("tm.Len should equal 0, but actually equals %v\n", tm.Len())
+	}
+}
+
+func TestLenSweepsExpiredEntries(t *testing.T) {
+	ttl := time.Second * 1
+	capacity := uint(3)
+	pruneInterval := time.Hour
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("a", "1")
+	tm.Put("b", "2")
+	time.Sleep(ttl + time.Millisecond*100)
+
+	if tm.Len() != 0 {
+		t.Fatalf("tm.Len should equal 0, but actually equals %v\n", tm.Len())
+	}
+	tm.lock.RLock()
+	remaining := len(tm.entries)
+	tm.lock.RUnlock()
+	if remaining != 0 {
+		t.Fatalf("Len should have swept the expired entries, but %v remain\n", remaining)
+	}
+}
+
+func TestSampledPruneStrategy(t *testing.T) {
+	ttl := time.Second * 1
+	capacity := uint(20)
+	pruneInterval := time.Second * 1
+	tm := NewWithOptions[string, string](capacity, ttl, pruneInterval, Sampled(5, time.Millisecond*500))
+	defer tm.Close()
+
+	for i := 0; i < 20; i++ {
+		tm.Put(string(rune('a'+i)), "v")
+	}
+	if tm.Len() != 20 {
+		t.Fatalf("tm.Len should equal 20, but actually equals %v\n", tm.Len())
+	}
+
+	time.Sleep(ttl + pruneInterval*2)
+	// Check the raw entries before calling Len, which would otherwise
+	// sweep expired entries itself and hide whether the pruner ran.
+	tm.lock.RLock()
+	remaining := len(tm.entries)
+	tm.lock.RUnlock()
+	if remaining != 0 {
+		t.Errorf("the sampled pruner should have removed every entry, but %v remain\n", remaining)
+	}
+	t.Logf("tm.Len: %v\n", tm.Len())
+	if tm.Len() != 0 {
+		t.Errorf("t.Len should be 0, but actually equals %v\n", tm.Len())
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	ttl := time.Minute
+	capacity := uint(10)
+	pruneInterval := time.Minute
+	src := NewSerializable[string, string](capacity, ttl, pruneInterval)
+	defer src.Close()
+
+	src.Put("myString", "a b c")
+	src.PutWithTTL("forever", "never expires", NoExpiration)
+
+	var buf bytes.Buffer
+	if err := src.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+
+	dst := NewSerializable[string, string](capacity, ttl, pruneInterval)
+	defer dst.Close()
+	if err := dst.LoadFrom(&buf); err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+
+	if value, ok := dst.Get("myString"); !ok || value != "a b c" {
+		t.Fatalf("\"myString\" should equal \"a b c\", got value=%v ok=%v", value, ok)
+	}
+	if value, ok := dst.Get("forever"); !ok || value != "never expires" {
+		t.Fatalf("\"forever\" should equal \"never expires\", got value=%v ok=%v", value, ok)
+	}
+	if dst.Len() != 2 {
+		t.Fatalf("dst.Len should equal 2, but actually equals %v\n", dst.Len())
+	}
+}
+
+func TestLoadDropsAlreadyExpiredEntries(t *testing.T) {
+	ttl := time.Minute
+	capacity := uint(10)
+	pruneInterval := time.Minute
+	src := NewSerializable[string, string](capacity, ttl, pruneInterval)
+	defer src.Close()
+
+	src.SetWithExpiration("stale", "old", time.Now().Add(-time.Second))
+	src.Put("fresh", "new")
+
+	var buf bytes.Buffer
+	if err := src.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+
+	dst := NewSerializable[string, string](capacity, ttl, pruneInterval)
+	defer dst.Close()
+	if err := dst.LoadFrom(&buf); err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+
+	if _, ok := dst.Get("stale"); ok {
+		t.Fatalf("\"stale\" should have been dropped on load")
+	}
+	if dst.Len() != 1 {
+		t.Fatalf("dst.Len should equal 1, but actually equals %v\n", dst.Len())
+	}
+}
+
+func TestLoadFromCorruptStream(t *testing.T) {
+	ttl := time.Minute
+	capacity := uint(10)
+	pruneInterval := time.Minute
+	dst := NewSerializable[string, string](capacity, ttl, pruneInterval)
+	defer dst.Close()
+
+	err := dst.LoadFrom(strings.NewReader("not a gob stream"))
+	if err == nil {
+		t.Fatalf("LoadFrom should return an error for a corrupt stream")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(3)
+	pruneInterval := time.Second * 10
+	tm := New[string, int](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Update("counter", func(old int, exists bool) (int, bool) {
+		if exists {
+			t.Fatalf("counter should not exist yet")
+		}
+		return old + 1, true
+	})
+	tm.Update("counter", func(old int, exists bool) (int, bool) {
+		if !exists {
+			t.Fatalf("counter should exist by now")
+		}
+		return old + 1, true
+	})
+	if value, _ := tm.Get("counter"); value != 2 {
+		t.Fatalf("counter should equal 2, but actually equals %v\n", value)
+	}
+
+	tm.Update("counter", func(old int, exists bool) (int, bool) {
+		return old, false
+	})
+	if _, ok := tm.Get("counter"); ok {
+		t.Fatalf("counter should have been removed by Update's keep=false")
+	}
+}
+
+func TestTouch(t *testing.T) {
+	ttl := time.Second * 2
+	capacity := uint(3)
+	pruneInterval := time.Hour
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("myString", "a b c")
+	time.Sleep(time.Second * 1)
+	if !tm.Touch("myString") {
+		t.Fatalf("Touch should return true for a present key")
+	}
+	time.Sleep(time.Second * 1)
+	if value, ok := tm.Get("myString"); !ok || value != "a b c" {
+		t.Fatalf("\"myString\" should still be present after Touch extended its ttl, got value=%v ok=%v", value, ok)
+	}
+
+	if tm.Touch("missing") {
+		t.Fatalf("Touch should return false for an absent key")
+	}
+}
+
+func TestPutIfAbsent(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(3)
+	pruneInterval := time.Second * 10
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	actual, inserted := tm.PutIfAbsent("myString", "first")
+	if !inserted || actual != "first" {
+		t.Fatalf("first PutIfAbsent should insert \"first\", got actual=%v inserted=%v", actual, inserted)
+	}
+
+	actual, inserted = tm.PutIfAbsent("myString", "second")
+	if inserted || actual != "first" {
+		t.Fatalf("second PutIfAbsent should not overwrite, got actual=%v inserted=%v", actual, inserted)
+	}
+}
+
+func TestEntryAPI(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(3)
+	pruneInterval := time.Second * 10
+	tm := New[string, int](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	if value := tm.Entry("counter").OrInsert(1); value != 1 {
+		t.Fatalf("OrInsert on an absent key should return 1, but returned %v\n", value)
+	}
+	if value := tm.Entry("counter").OrInsert(100); value != 1 {
+		t.Fatalf("OrInsert on a present key should return the existing value 1, but returned %v\n", value)
+	}
+
+	tm.Entry("counter").AndModify(func(v *int) { *v += 1 })
+	if value, _ := tm.Get("counter"); value != 2 {
+		t.Fatalf("counter should equal 2 after AndModify, but actually equals %v\n", value)
+	}
+
+	// AndModify on an absent key is a no-op, so OrInsert still applies.
+	value := tm.Entry("lazy").AndModify(func(v *int) { *v += 1 }).OrInsert(5)
+	if value != 5 {
+		t.Fatalf("Entry(\"lazy\").AndModify(...).OrInsert(5) should equal 5, but actually equals %v\n", value)
+	}
+
+	calls := 0
+	tm.Entry("lazy").OrInsertWith(func() int { calls++; return 42 })
+	if calls != 0 {
+		t.Fatalf("OrInsertWith should not call its func when the key is already present")
+	}
+
+	removedValue, removed := tm.Entry("counter").Remove()
+	if !removed || removedValue != 2 {
+		t.Fatalf("Remove should return (2, true), but returned (%v, %v)", removedValue, removed)
+	}
+	if _, ok := tm.Get("counter"); ok {
+		t.Fatalf("\"counter\" should be gone after Remove")
+	}
+}
+
+func TestAllKeysValues(t *testing.T) {
+	ttl := time.Second * 10
+	capacity := uint(5)
+	pruneInterval := time.Second * 10
+	tm := New[string, int](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("a", 1)
+	tm.Put("b", 2)
+	tm.Put("c", 3)
+
+	seen := map[string]int{}
+	for k, v := range tm.All() {
+		seen[k] = v
+	}
+	if len(seen) != 3 || seen["a"] != 1 || seen["b"] != 2 || seen["c"] != 3 {
+		t.Fatalf("All() should yield all three live entries, got %v", seen)
+	}
+
+	keys := map[string]bool{}
+	for k := range tm.Keys() {
+		keys[k] = true
+	}
+	if len(keys) != 3 || !keys["a"] || !keys["b"] || !keys["c"] {
+		t.Fatalf("Keys() should yield all three live keys, got %v", keys)
+	}
+
+	sum := 0
+	for v := range tm.Values() {
+		sum += v
+	}
+	if sum != 6 {
+		t.Fatalf("Values() should sum to 6, but summed to %v", sum)
+	}
+
+	count := 0
+	tm.ForEach(func(k string, v int) bool {
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Fatalf("ForEach should visit 3 entries, but visited %v", count)
+	}
+
+	var stoppedAt int
+	tm.ForEach(func(k string, v int) bool {
+		stoppedAt++
+		return false
+	})
+	if stoppedAt != 1 {
+		t.Fatalf("ForEach should stop after the first false return, but ran %v times", stoppedAt)
+	}
+}
+
+func TestAllSkipsExpiredEntries(t *testing.T) {
+	ttl := time.Second * 1
+	capacity := uint(3)
+	pruneInterval := time.Hour
+	tm := New[string, string](capacity, ttl, pruneInterval)
+	defer tm.Close()
+
+	tm.Put("stale", "old")
+	tm.PutWithTTL("forever", "never expires", NoExpiration)
+	time.Sleep(ttl + time.Millisecond*100)
+
+	count := 0
+	for k := range tm.All() {
+		if k != "forever" {
+			t.Fatalf("All() should have skipped the expired \"stale\" entry, saw %v", k)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("All() should yield exactly 1 live entry, but yielded %v", count)
+	}
+}
+
 func TestUInt64Key(t *testing.T) {
 	ttl := time.Second * 2
 	capacity := uint(3)
@@ -261,9 +719,8 @@ func TestUInt64Key(t *testing.T) {
 	tm.Put(9223372036854776000, "mid")
 	tm.Put(0, "zero")
 
-	allItems := tm.Copy()
-	for k, v := range allItems {
-		t.Logf("k: %v   v: %v\n", k, v.value)
+	for k, v := range tm.All() {
+		t.Logf("k: %v   v: %v\n", k, v)
 	}
 
 	time.Sleep(ttl + pruneInterval)
@@ -284,9 +741,8 @@ func TestUFloat32Key(t *testing.T) {
 	tm.Put(12312312312.98765, "mid")
 	tm.Put(0.001, "tiny")
 
-	allItems := tm.Copy()
-	for k, v := range allItems {
-		t.Logf("k: %v   v: %v\n", k, v.value)
+	for k, v := range tm.All() {
+		t.Logf("k: %v   v: %v\n", k, v)
 	}
 	t.Logf("k: 0.001   v:%v   (verified)\n", tm.GetOrZero(0.001))
 
@@ -309,9 +765,8 @@ func TestByteKey(t *testing.T) {
 	tm.Put(0x41, "A")
 	tm.Put(0x7a, "z")
 
-	allItems := tm.Copy()
-	for k, v := range allItems {
-		t.Logf("k: %x   v: %v\n", k, v.value)
+	for k, v := range tm.All() {
+		t.Logf("k: %x   v: %v\n", k, v)
 	}
 	time.Sleep(ttl + pruneInterval)
 	t.Logf("tm.Len: %v\n", tm.Len())